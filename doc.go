@@ -22,6 +22,11 @@ skipping the defined number of functions from the stack information.
 	if you want to wrap an existing error and add notifier options use WrapWithNotifier
 	if you want to wrap an existing error, skip some functions on the stack and add notifier options use WrapWithSkipAndNotifier
 
+Errors returned by this package implement Unwrap, so errors.Is/errors.As from
+the standard library traverse Wrap/WrapWithSkip/WrapWithStatus/WrapWithNotifier
+chains correctly, and the errors.Join helper here defers to the standard
+library so multiple sub-errors can be notified as one event.
+
 Head to https://docs.coldbrew.cloud for more information.
 */
 package errors
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"runtime"
 
 	"google.golang.org/grpc/status"
@@ -16,10 +17,15 @@ type StackFrame struct {
 }
 
 // ErrorExt is the interface implemented by errors created by this package.
+// It satisfies the standard error interface and is compatible with
+// errors.Is/errors.As/errors.Unwrap from https://golang.org/pkg/errors/.
 type ErrorExt interface {
 	error
 	// Cause returns the error this one wraps, or itself if it wraps nothing.
 	Cause() error
+	// Unwrap returns the error this one wraps, or nil. It is what makes
+	// ErrorExt participate in errors.Is/errors.As chains.
+	Unwrap() error
 	// Callers returns the raw program counters captured when the error
 	// was created, suitable for runtime.CallersFrames.
 	Callers() []uintptr
@@ -64,6 +70,11 @@ func (e *errorExt) Cause() error {
 	return e
 }
 
+// Unwrap returns the error this one wraps, or nil.
+func (e *errorExt) Unwrap() error {
+	return e.cause
+}
+
 func (e *errorExt) Callers() []uintptr {
 	return e.pcs
 }
@@ -176,3 +187,28 @@ func WrapWithSkipAndNotifier(err error, msg string, skip int, notify bool) Error
 	e.(*errorExt).silence = !notify
 	return e
 }
+
+// Is reports whether any error in err's chain matches target. ErrorExt
+// errors participate via Unwrap, so Is sees through Wrap/WrapWithSkip/
+// WrapWithStatus/WrapWithNotifier just like the standard library.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, assigning it
+// to *target per the standard library errors.As semantics.
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap returns the result of calling the Unwrap method on err, if any.
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
+
+// Join is errors.Join from the standard library, re-exported so callers
+// don't need to import both packages. notifier.Notify renders each
+// sub-error of a joined error as its own Sentry Exception entry.
+func Join(errs ...error) error {
+	return stderrors.Join(errs...)
+}
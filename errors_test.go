@@ -0,0 +1,117 @@
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsTraversesWrapChain(t *testing.T) {
+	sentinel := stderrors.New("sentinel")
+	wrapped := Wrap(sentinel, "outer")
+
+	if !Is(wrapped, sentinel) {
+		t.Fatalf("Is should see sentinel through a single Wrap")
+	}
+
+	doubleWrapped := Wrap(wrapped, "outermost")
+	if !Is(doubleWrapped, sentinel) {
+		t.Fatalf("Is should see sentinel through a chain of Wraps")
+	}
+}
+
+func TestIsTraversesWrapWithStatusAndNotifierChains(t *testing.T) {
+	sentinel := stderrors.New("sentinel")
+
+	withStatus := WrapWithStatus(sentinel, "has status", status.New(codes.NotFound, "nope"))
+	if !Is(withStatus, sentinel) {
+		t.Fatalf("Is should see sentinel through WrapWithStatus")
+	}
+
+	withNotifier := WrapWithNotifier(withStatus, "silenced", false)
+	if !Is(withNotifier, sentinel) {
+		t.Fatalf("Is should see sentinel through WrapWithNotifier")
+	}
+}
+
+func TestAsFindsTypedErrorInChain(t *testing.T) {
+	st := status.New(codes.NotFound, "nope")
+	inner := NewWithStatus("not found", st)
+	wrapped := Wrap(inner, "outer")
+
+	// As binds to the first node in the chain assignable to *target, which
+	// is wrapped itself here since every *errorExt implements ErrorExt
+	// regardless of whether a status was attached to it - same reason
+	// notifier.grpcStatusOf walks the chain manually instead of using As.
+	var target ErrorExt
+	if !As(wrapped, &target) {
+		t.Fatalf("As should find an ErrorExt in the chain")
+	}
+	if target != wrapped {
+		t.Fatalf("As should bind to the outermost ErrorExt node")
+	}
+	if target.GRPCStatus() != nil {
+		t.Fatalf("outermost node should not carry the inner node's status")
+	}
+
+	var inspect ErrorExt = target
+	for inspect.GRPCStatus() == nil {
+		cause, ok := Unwrap(inspect).(ErrorExt)
+		if !ok {
+			t.Fatalf("walked off the chain without finding the attached status")
+		}
+		inspect = cause
+	}
+	if inspect.GRPCStatus() != st {
+		t.Fatalf("manual chain walk found the wrong status")
+	}
+}
+
+func TestUnwrapReturnsCause(t *testing.T) {
+	sentinel := stderrors.New("sentinel")
+	wrapped := Wrap(sentinel, "outer")
+
+	if got := Unwrap(wrapped); got != sentinel {
+		t.Fatalf("Unwrap(wrapped) = %v, want sentinel", got)
+	}
+
+	if got := Unwrap(New("no cause")); got != nil {
+		t.Fatalf("Unwrap of a non-wrapping error should be nil, got %v", got)
+	}
+}
+
+func TestJoinIsVisibleToIs(t *testing.T) {
+	a := New("a")
+	b := New("b")
+	joined := Join(a, b)
+
+	if !Is(joined, a) {
+		t.Fatalf("Is should see a through Join")
+	}
+	if !Is(joined, b) {
+		t.Fatalf("Is should see b through Join")
+	}
+}
+
+func TestWrapWithSkipPreservesOriginalStack(t *testing.T) {
+	inner := New("inner")
+	wrapped := Wrap(inner, "outer")
+
+	if len(wrapped.Callers()) != len(inner.Callers()) {
+		t.Fatalf("Wrap should keep the original error's stack instead of capturing a new one")
+	}
+}
+
+func TestWrapNilReturnsNil(t *testing.T) {
+	if Wrap(nil, "msg") != nil {
+		t.Fatalf("Wrap(nil, ...) should return nil")
+	}
+	if WrapWithStatus(nil, "msg", nil) != nil {
+		t.Fatalf("WrapWithStatus(nil, ...) should return nil")
+	}
+	if WrapWithNotifier(nil, "msg", true) != nil {
+		t.Fatalf("WrapWithNotifier(nil, ...) should return nil")
+	}
+}
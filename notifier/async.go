@@ -0,0 +1,186 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// BackpressurePolicy controls what the async worker pool started by
+// StartAsync does once its job queue is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the caller until a worker frees up room in
+	// the queue. This is the default.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest drops the oldest queued job to make room for
+	// the new one, counted in GetStats().Dropped.
+	BackpressureDropOldest
+)
+
+type notifyJob struct {
+	err     error
+	rawData []interface{}
+}
+
+type asyncPool struct {
+	jobs   chan notifyJob
+	policy BackpressurePolicy
+	wg     sync.WaitGroup
+
+	// closeMu guards closed and serializes it against enqueue's send, so
+	// CloseWithContext can never close(jobs) while enqueue is mid-send on
+	// the same channel (which would panic with "send on closed channel").
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+var (
+	asyncMu sync.Mutex
+	async   *asyncPool
+	// asyncClosed is set once CloseWithContext has run and cleared by the
+	// next explicit StartAsync/StartAsyncWithPolicy, so asyncPoolOrDefault
+	// knows not to resurrect a pool behind a caller who just shut one down.
+	asyncClosed bool
+
+	statsDropped uint64
+)
+
+// StartAsync starts a worker pool of the given size backed by a queue of
+// queueSize pending jobs; NotifyWithExclude enqueues onto this pool instead
+// of spawning an unbounded goroutine per call. Calling StartAsync again
+// replaces the previous pool without draining it, so prefer CloseWithContext
+// for a graceful shutdown.
+func StartAsync(workers, queueSize int) {
+	StartAsyncWithPolicy(workers, queueSize, BackpressureBlock)
+}
+
+// StartAsyncWithPolicy is StartAsync with an explicit backpressure policy.
+func StartAsyncWithPolicy(workers, queueSize int, policy BackpressurePolicy) {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	startAsyncLocked(workers, queueSize, policy)
+}
+
+// startAsyncLocked replaces the current pool with a freshly started one.
+// Callers must hold asyncMu.
+func startAsyncLocked(workers, queueSize int, policy BackpressurePolicy) {
+	p := &asyncPool{
+		jobs:   make(chan notifyJob, queueSize),
+		policy: policy,
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+	async = p
+	asyncClosed = false
+}
+
+func (p *asyncPool) run() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		Notify(job.err, job.rawData...)
+	}
+}
+
+// enqueue sends job onto the pool's channel. It holds closeMu for the
+// duration of the send so closeJobs can't close the channel underneath it.
+func (p *asyncPool) enqueue(job notifyJob) {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return
+	}
+
+	if p.policy == BackpressureDropOldest {
+		select {
+		case p.jobs <- job:
+			return
+		default:
+		}
+		select {
+		case <-p.jobs:
+			atomic.AddUint64(&statsDropped, 1)
+		default:
+		}
+		select {
+		case p.jobs <- job:
+		default:
+			atomic.AddUint64(&statsDropped, 1)
+		}
+		return
+	}
+	p.jobs <- job
+}
+
+// closeJobs closes the pool's job channel exactly once, blocking until any
+// enqueue call already in flight has finished its send.
+func (p *asyncPool) closeJobs() {
+	p.closeMu.Lock()
+	defer p.closeMu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+}
+
+// asyncPoolOrDefault returns the active async pool, lazily starting a
+// default-sized one on first use. It returns nil if CloseWithContext has
+// already run and nothing has called StartAsync/StartAsyncWithPolicy since,
+// so callers must treat a nil result as "shutting down, don't enqueue".
+func asyncPoolOrDefault() *asyncPool {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	if async == nil && !asyncClosed {
+		startAsyncLocked(4, 1024, BackpressureBlock)
+	}
+	return async
+}
+
+// QueueDepth returns the number of jobs currently queued on the async pool,
+// or 0 if nothing has been enqueued yet.
+func QueueDepth() int {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	if async == nil {
+		return 0
+	}
+	return len(async.jobs)
+}
+
+// CloseWithContext stops accepting new async jobs, waits (up to ctx's
+// deadline) for queued and in-flight Notify calls to finish, then closes
+// every registered backend. Close is CloseWithContext(context.Background()).
+func CloseWithContext(ctx context.Context) {
+	asyncMu.Lock()
+	p := async
+	async = nil
+	asyncClosed = true
+	asyncMu.Unlock()
+
+	if p != nil {
+		p.closeJobs()
+		done := make(chan struct{})
+		go func() {
+			p.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+
+	for _, b := range registeredBackends() {
+		b.Close(ctx)
+	}
+}
+
+// Close closes every registered backend and flushes its error queue.
+// You should call Close before app shutdown.
+// Close doesn't call os.Exit.
+func Close() {
+	CloseWithContext(context.Background())
+}
@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+)
+
+func TestAsyncPoolOrDefaultDoesNotReplaceAnExplicitPool(t *testing.T) {
+	StartAsync(1, 0)
+	defer CloseWithContext(context.Background())
+
+	p := asyncPoolOrDefault()
+	if p != async {
+		t.Fatalf("asyncPoolOrDefault() returned a different pool than the explicitly started one")
+	}
+}
+
+func TestNotifyWithExcludeNoOpsAfterClose(t *testing.T) {
+	StartAsync(1, 1)
+	CloseWithContext(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		NotifyWithExclude(errors.New("after close"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("NotifyWithExclude after Close should return promptly instead of blocking/panicking")
+	}
+}
+
+func TestAsyncPoolOrDefaultRestartsAfterClose(t *testing.T) {
+	StartAsync(1, 1)
+	CloseWithContext(context.Background())
+
+	if p := asyncPoolOrDefault(); p != nil {
+		t.Fatalf("asyncPoolOrDefault() should return nil right after Close, before anything restarts it")
+	}
+
+	StartAsync(1, 1)
+	defer CloseWithContext(context.Background())
+
+	if p := asyncPoolOrDefault(); p == nil {
+		t.Fatalf("asyncPoolOrDefault() should return the freshly started pool")
+	}
+}
+
+// TestConcurrentNotifyAndCloseDoesNotPanic guards against enqueue racing
+// CloseWithContext's close(p.jobs): NotifyWithExclude looks up the pool and
+// sends to it in two separate steps, so a close landing in between used to
+// panic with "send on closed channel" (and race under -race).
+func TestConcurrentNotifyAndCloseDoesNotPanic(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		StartAsync(1, 0)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				NotifyWithExclude(errors.New("concurrent"))
+			}()
+		}
+
+		CloseWithContext(context.Background())
+		wg.Wait()
+	}
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+)
+
+// defaultCloseTimeout bounds how long a Backend.Close implementation blocks
+// flushing pending work when ctx carries no deadline of its own.
+const defaultCloseTimeout = 5 * time.Second
+
+// closeTimeout derives how long a Backend.Close implementation may spend
+// flushing pending work out of ctx: its remaining deadline if it has one
+// (zero if that deadline has already passed), else defaultCloseTimeout.
+func closeTimeout(ctx context.Context) time.Duration {
+	if dl, ok := ctx.Deadline(); ok {
+		if d := time.Until(dl); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultCloseTimeout
+}
+
+// Backend is a pluggable error-notification sink. InitAirbrake, InitRollbar
+// and InitSentry each build an adapter around their respective client and
+// Register it under the hood, so existing callers don't need to change.
+// Anything else (Datadog, OpenTelemetry, an in-house pager) can implement
+// Backend and call Register directly to receive the same notifications.
+type Backend interface {
+	// Name identifies the backend. It is used as the registry key, so
+	// Register-ing a second backend with the same name replaces the first.
+	Name() string
+	// Notify sends a single error notification. tags and extra are already
+	// merged from rawData, context log fields, and WithScope.
+	Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error
+	// NotifyPanic sends a notification for a recovered panic. r is the
+	// recovered value.
+	NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error
+	// Close flushes any notifications still in flight and releases any
+	// resources held by the backend. ctx's deadline, if any, bounds how
+	// long the flush may block; see closeTimeout.
+	Close(ctx context.Context) error
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// Register adds b to the set of backends notified by Notify/NotifyOnPanic.
+func Register(b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[b.Name()] = b
+}
+
+// Unregister removes the backend registered under name, if any.
+func Unregister(name string) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	delete(backends, name)
+}
+
+func registeredBackends() []Backend {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	out := make([]Backend, 0, len(backends))
+	for _, b := range backends {
+		out = append(out, b)
+	}
+	return out
+}
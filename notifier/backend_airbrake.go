@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/go-coldbrew/errors"
+	gobrake "gopkg.in/airbrake/gobrake.v2"
+)
+
+var airbrake *gobrake.Notifier
+
+// InitAirbrake inits airbrake configuration
+// projectID: airbrake project id
+// projectKey: airbrake project key
+func InitAirbrake(projectID int64, projectKey string) {
+	airbrake = gobrake.NewNotifier(projectID, projectKey)
+	Register(&airbrakeBackend{notifier: airbrake})
+}
+
+func convToGoBrake(in []errors.StackFrame) []gobrake.StackFrame {
+	out := make([]gobrake.StackFrame, 0)
+	for _, s := range in {
+		out = append(out, gobrake.StackFrame{
+			File: s.File,
+			Func: s.Func,
+			Line: s.Line,
+		})
+	}
+	return out
+}
+
+// airbrakeBackend adapts the gobrake notifier to the Backend interface.
+type airbrakeBackend struct {
+	notifier *gobrake.Notifier
+}
+
+func (b *airbrakeBackend) Name() string { return "airbrake" }
+
+func (b *airbrakeBackend) Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error {
+	n := gobrake.NewNotice(err, nil, 1)
+	n.Errors[0].Backtrace = convToGoBrake(err.StackFrame())
+	for k, v := range extra {
+		n.Context[k] = v
+	}
+	for k, v := range tags {
+		n.Context[k] = v
+	}
+	b.notifier.SendNoticeAsync(n)
+	return nil
+}
+
+func (b *airbrakeBackend) NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error {
+	return b.Notify(ctx, err, "critical", tags, extra)
+}
+
+// Close flushes gobrake's pending notices and closes the notifier, bounded
+// by ctx's deadline.
+func (b *airbrakeBackend) Close(ctx context.Context) error {
+	return b.notifier.CloseTimeout(closeTimeout(ctx))
+}
@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+)
+
+const datadogLogsIntakeURL = "https://http-intake.logs.datadoghq.com/api/v2/logs"
+
+// DatadogBackend ships notified errors to the Datadog Logs HTTP intake, for
+// stacks that rely on Datadog instead of any of the legacy SaaS agents.
+// Construct one with NewDatadogBackend and notifier.Register it.
+type DatadogBackend struct {
+	apiKey  string
+	service string
+	client  *http.Client
+}
+
+// NewDatadogBackend returns a Backend that POSTs errors to Datadog's logs
+// intake. service is reported as the `service` field on every log entry.
+func NewDatadogBackend(apiKey, service string) *DatadogBackend {
+	return &DatadogBackend{
+		apiKey:  apiKey,
+		service: service,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type datadogLogEntry struct {
+	Message   string                 `json:"message"`
+	Service   string                 `json:"service"`
+	Status    string                 `json:"status"`
+	DDTraceID string                 `json:"dd.trace_id,omitempty"`
+	Stack     string                 `json:"error.stack,omitempty"`
+	Tags      map[string]string      `json:"tags,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+func (b *DatadogBackend) Name() string { return "datadog" }
+
+func (b *DatadogBackend) Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error {
+	return b.send(ctx, datadogLogEntry{
+		Message:   err.Error(),
+		Service:   b.service,
+		Status:    level,
+		DDTraceID: GetTraceId(ctx),
+		Stack:     stackFramesToString(err.StackFrame()),
+		Tags:      tags,
+		Extra:     extra,
+	})
+}
+
+func (b *DatadogBackend) NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error {
+	return b.Notify(ctx, err, "critical", tags, extra)
+}
+
+func (b *DatadogBackend) send(ctx context.Context, entry datadogLogEntry) error {
+	body, err := json.Marshal([]datadogLogEntry{entry})
+	if err != nil {
+		return err
+	}
+	// Notify reaches us off the async worker pool (see StartAsync), well
+	// after the request/RPC that produced ctx may have returned and
+	// cancelled it. Use a fresh, independently-timed context for the
+	// outbound POST so it isn't cancelled along with the caller's ctx.
+	sendCtx, cancel := context.WithTimeout(context.Background(), b.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, datadogLogsIntakeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", b.apiKey)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+func (b *DatadogBackend) Close(ctx context.Context) error { return nil }
+
+func stackFramesToString(frames []errors.StackFrame) string {
+	var buf bytes.Buffer
+	for _, f := range frames {
+		fmt.Fprintf(&buf, "%s:%d %s\n", f.File, f.Line, f.Func)
+	}
+	return buf.String()
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/go-coldbrew/errors"
+	stdopentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelBackend records notified errors as span events on whatever tracing
+// span ctx already carries. Most callers here run under this package's
+// existing opentracing integration (see SetTraceId/GetTraceId), so that
+// span, found via stdopentracing.SpanFromContext, is logged via
+// Span.LogFields the same way opentracing error instrumentation normally
+// does it. A ctx carrying a recording OTel span (trace.SpanFromContext) is
+// also recorded on directly, so the two integrations can coexist.
+type otelBackend struct{}
+
+// NewOTelBackend returns a Backend that records errors on the tracing span
+// found in ctx, opentracing or OpenTelemetry. It does nothing when ctx
+// carries neither. Register it with notifier.Register to enable it.
+func NewOTelBackend() Backend {
+	return otelBackend{}
+}
+
+func (otelBackend) Name() string { return "otel" }
+
+func (otelBackend) Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error {
+	if span := stdopentracing.SpanFromContext(ctx); span != nil {
+		fields := make([]otlog.Field, 0, len(tags)+2)
+		fields = append(fields, otlog.Event("error"), otlog.String("message", err.Error()))
+		for k, v := range tags {
+			fields = append(fields, otlog.String(k, v))
+		}
+		ext.Error.Set(span, true)
+		span.LogFields(fields...)
+	}
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		attrs := make([]attribute.KeyValue, 0, len(tags))
+		for k, v := range tags {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+		span.RecordError(err, trace.WithAttributes(attrs...))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return nil
+}
+
+func (b otelBackend) NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error {
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tags["panic"] = "true"
+	return b.Notify(ctx, err, "critical", tags, extra)
+}
+
+func (otelBackend) Close(ctx context.Context) error { return nil }
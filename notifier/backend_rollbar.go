@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+	"github.com/stvp/rollbar"
+)
+
+var rollbarInited bool
+
+// InitRollbar inits rollbar configuration
+// token: rollbar token
+// env: rollbar environment
+func InitRollbar(token, env string) {
+	rollbar.Token = token
+	rollbar.Environment = env
+	rollbarInited = true
+	Register(rollbarBackend{})
+}
+
+func convToRollbar(in []errors.StackFrame) rollbar.Stack {
+	out := rollbar.Stack{}
+	for _, s := range in {
+		out = append(out, rollbar.Frame{
+			Filename: s.File,
+			Method:   s.Func,
+			Line:     s.Line,
+		})
+	}
+	return out
+}
+
+// rollbarBackend adapts the stvp/rollbar client to the Backend interface.
+type rollbarBackend struct{}
+
+func (rollbarBackend) Name() string { return "rollbar" }
+
+func (rollbarBackend) Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error {
+	fields := fieldsFor(extra, tags)
+	fields = append(fields, &rollbar.Field{Name: "server", Data: map[string]interface{}{"hostname": getHostname(), "root": getServerRoot()}})
+	rollbar.ErrorWithStack(level, err, convToRollbar(err.StackFrame()), fields...)
+	return nil
+}
+
+func (rollbarBackend) NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error {
+	fields := append([]*rollbar.Field{{Name: "panic", Data: r}}, fieldsFor(extra, tags)...)
+	rollbar.ErrorWithStack(rollbar.CRIT, err, convToRollbar(err.StackFrame()), fields...)
+	return nil
+}
+
+// Close waits for rollbar's queued reports to finish sending. rollbar.Wait
+// has no timeout of its own, so it runs in a goroutine bounded by ctx.
+func (rollbarBackend) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		rollbar.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(closeTimeout(ctx)):
+	}
+	return nil
+}
+
+func fieldsFor(extra map[string]interface{}, tags map[string]string) []*rollbar.Field {
+	fields := make([]*rollbar.Field, 0, len(extra)+len(tags))
+	for k, v := range extra {
+		fields = append(fields, &rollbar.Field{Name: k, Data: v})
+	}
+	for k, v := range tags {
+		fields = append(fields, &rollbar.Field{Name: k, Data: v})
+	}
+	return fields
+}
@@ -0,0 +1,260 @@
+package notifier
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+
+	sentry "github.com/getsentry/sentry-go"
+	"github.com/go-coldbrew/errors"
+)
+
+var (
+	sentryInited      bool
+	sentryEnvironment string
+	sentryRelease     string
+)
+
+// Breadcrumb is a sentry breadcrumb, re-exported so callers don't need to
+// import sentry-go directly just to call AddBreadcrumb.
+type Breadcrumb = sentry.Breadcrumb
+
+// ScopeFunc configures a sentry scope, see WithScope.
+type ScopeFunc func(*sentry.Scope)
+
+// requestStateKey is the context.WithValue key for *requestState. It is
+// kept off the shared options.Options map deliberately: that map is a
+// plain map[string]interface{} mutated in place by options.AddToOptions,
+// so two goroutines sharing the same parent ctx calling AddBreadcrumb or
+// WithScope concurrently would race on the same map writes.
+type requestStateKey struct{}
+
+// requestState accumulates the breadcrumbs/scope funcs recorded on a
+// request's context. It is safe for concurrent use by goroutines that
+// share the same parent ctx.
+type requestState struct {
+	mu          sync.Mutex
+	breadcrumbs []Breadcrumb
+	scopeFuncs  []ScopeFunc
+}
+
+func (s *requestState) addBreadcrumb(b Breadcrumb) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breadcrumbs = append(s.breadcrumbs, b)
+}
+
+func (s *requestState) addScopeFunc(fn ScopeFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopeFuncs = append(s.scopeFuncs, fn)
+}
+
+func (s *requestState) snapshotBreadcrumbs() []Breadcrumb {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Breadcrumb(nil), s.breadcrumbs...)
+}
+
+func (s *requestState) snapshotScopeFuncs() []ScopeFunc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScopeFunc(nil), s.scopeFuncs...)
+}
+
+func requestStateFromContext(ctx context.Context) (*requestState, bool) {
+	s, ok := ctx.Value(requestStateKey{}).(*requestState)
+	return s, ok
+}
+
+// ensureRequestState returns ctx's *requestState, creating and attaching one
+// if this is the first call for ctx's lineage.
+func ensureRequestState(ctx context.Context) (context.Context, *requestState) {
+	if s, ok := requestStateFromContext(ctx); ok {
+		return ctx, s
+	}
+	s := &requestState{}
+	return context.WithValue(ctx, requestStateKey{}, s), s
+}
+
+// InitSentry inits sentry configuration
+// dsn: sentry dsn
+func InitSentry(dsn string) {
+	sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: sentryEnvironment,
+		Release:     sentryRelease,
+	})
+	sentryInited = true
+	Register(sentryBackend{})
+}
+
+// AddBreadcrumb records a sentry breadcrumb on ctx. Breadcrumbs accumulate
+// until the context is passed to Notify/NotifyOnPanic, at which point they
+// are flushed onto the resulting sentry event.
+func AddBreadcrumb(ctx context.Context, b Breadcrumb) context.Context {
+	ctx, s := ensureRequestState(ctx)
+	s.addBreadcrumb(b)
+	return ctx
+}
+
+func breadcrumbsFromContext(ctx context.Context) []Breadcrumb {
+	if s, ok := requestStateFromContext(ctx); ok {
+		return s.snapshotBreadcrumbs()
+	}
+	return nil
+}
+
+// WithScope registers fn to configure the sentry scope used for any
+// notification carrying ctx. This is how callers attach user/request info
+// (e.g. scope.SetUser, scope.SetTag) that should also propagate into the
+// tags/extra passed to every other registered Backend.
+func WithScope(ctx context.Context, fn ScopeFunc) context.Context {
+	ctx, s := ensureRequestState(ctx)
+	s.addScopeFunc(fn)
+	return ctx
+}
+
+func scopeFuncsFromContext(ctx context.Context) []ScopeFunc {
+	if s, ok := requestStateFromContext(ctx); ok {
+		return s.snapshotScopeFuncs()
+	}
+	return nil
+}
+
+// scopeExtras applies the scope funcs recorded on ctx to a throwaway sentry
+// event and returns the tags/extra they set, so every registered Backend
+// can carry the same user/request info that Sentry gets via WithScope.
+func scopeExtras(ctx context.Context) (tags map[string]string, extra map[string]interface{}) {
+	funcs := scopeFuncsFromContext(ctx)
+	if len(funcs) == 0 {
+		return nil, nil
+	}
+	scope := sentry.NewScope()
+	for _, fn := range funcs {
+		fn(scope)
+	}
+	event := scope.ApplyToEvent(&sentry.Event{}, nil)
+	if event == nil {
+		return nil, nil
+	}
+	return event.Tags, event.Extra
+}
+
+// newSentryHub returns a per-request sentry hub with any breadcrumbs and
+// scopes recorded on ctx (via AddBreadcrumb/WithScope) applied to it.
+func newSentryHub(ctx context.Context) *sentry.Hub {
+	hub := sentry.CurrentHub().Clone()
+	for _, b := range breadcrumbsFromContext(ctx) {
+		crumb := b
+		hub.Scope().AddBreadcrumb(&crumb, 100)
+	}
+	for _, fn := range scopeFuncsFromContext(ctx) {
+		fn(hub.Scope())
+	}
+	return hub
+}
+
+func convToSentry(in errors.ErrorExt) *sentry.Stacktrace {
+	out := new(sentry.Stacktrace)
+	pcs := in.Callers()
+	frames := make([]sentry.Frame, 0)
+
+	callersFrames := runtime.CallersFrames(pcs)
+
+	for {
+		fr, more := callersFrames.Next()
+		if fr.Func != nil {
+			frame := sentry.NewFrame(fr)
+			frame.InApp = true
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	for i := len(frames)/2 - 1; i >= 0; i-- {
+		opp := len(frames) - 1 - i
+		frames[i], frames[opp] = frames[opp], frames[i]
+	}
+	out.Frames = frames
+	return out
+}
+
+func sentryLevel(level string) sentry.Level {
+	switch level {
+	case "critical":
+		return sentry.LevelFatal
+	case "warning":
+		return sentry.LevelWarning
+	default:
+		return sentry.LevelError
+	}
+}
+
+// sentryBackend adapts sentry-go to the Backend interface.
+type sentryBackend struct{}
+
+func (sentryBackend) Name() string { return "sentry" }
+
+func (sentryBackend) Notify(ctx context.Context, err errors.ErrorExt, level string, tags map[string]string, extra map[string]interface{}) error {
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(level)
+	event.Message = err.Error()
+	event.Exception = sentryExceptions(err)
+	event.Extra = extra
+	event.Tags = tags
+	newSentryHub(ctx).CaptureEvent(event)
+	return nil
+}
+
+// sentryExceptions renders err as one or more sentry.Exception entries. An
+// error built with errors.Join renders as one entry per sub-error, each
+// with its own stack when the sub-error is itself an ErrorExt.
+func sentryExceptions(err errors.ErrorExt) []sentry.Exception {
+	var cur error = err
+	for cur != nil {
+		if joined, ok := cur.(interface{ Unwrap() []error }); ok {
+			subErrs := joined.Unwrap()
+			out := make([]sentry.Exception, 0, len(subErrs))
+			for _, sub := range subErrs {
+				out = append(out, sentryException(sub))
+			}
+			return out
+		}
+		u, ok := cur.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		cur = u.Unwrap()
+	}
+	return []sentry.Exception{sentryException(err)}
+}
+
+func sentryException(err error) sentry.Exception {
+	exc := sentry.Exception{
+		Value: err.Error(),
+		Type:  reflect.TypeOf(err).String(),
+	}
+	if ext, ok := err.(errors.ErrorExt); ok {
+		exc.Stacktrace = convToSentry(ext)
+	}
+	return exc
+}
+
+func (b sentryBackend) NotifyPanic(ctx context.Context, err errors.ErrorExt, r interface{}, tags map[string]string, extra map[string]interface{}) error {
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tags["panic"] = "true"
+	return b.Notify(ctx, err, "critical", tags, extra)
+}
+
+// Close flushes sentry-go's transport queue. CaptureEvent dispatches
+// asynchronously, so without this an event captured shortly before
+// shutdown can be silently dropped.
+func (sentryBackend) Close(ctx context.Context) error {
+	sentry.Flush(closeTimeout(ctx))
+	return nil
+}
@@ -4,29 +4,24 @@ import (
 	"context"
 	"os"
 	"reflect"
-	"runtime"
 	"strconv"
 	"strings"
 
-	raven "github.com/getsentry/raven-go"
+	sentry "github.com/getsentry/sentry-go"
 	"github.com/go-coldbrew/errors"
 	"github.com/go-coldbrew/log"
 	"github.com/go-coldbrew/log/loggers"
 	"github.com/go-coldbrew/options"
-	"github.com/google/uuid"
 	stdopentracing "github.com/opentracing/opentracing-go"
 	"github.com/stvp/rollbar"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	gobrake "gopkg.in/airbrake/gobrake.v2"
 )
 
 var (
-	airbrake      *gobrake.Notifier
-	rollbarInited bool
-	sentryInited  bool
-	serverRoot    string
-	hostname      string
-	traceHeader   string = "x-trace-id"
+	serverRoot  string
+	hostname    string
+	traceHeader string = "x-trace-id"
 )
 
 const (
@@ -50,87 +45,59 @@ type isTags interface {
 	value() map[string]string
 }
 
-type Tags map[string]string
-
-func (tags Tags) isTags() {}
-
-func (tags Tags) value() map[string]string {
-	return map[string]string(tags)
-}
-
-// InitAirbrake inits airbrake configuration
-// projectID: airbrake project id
-// projectKey: airbrake project key
-func InitAirbrake(projectID int64, projectKey string) {
-	airbrake = gobrake.NewNotifier(projectID, projectKey)
-}
-
-// InitRollbar inits rollbar configuration
-// token: rollbar token
-// env: rollbar environment
-func InitRollbar(token, env string) {
-	rollbar.Token = token
-	rollbar.Environment = env
-	rollbarInited = true
+// grpcStatuser matches the interface google.golang.org/grpc/status.FromError
+// checks for, so doNotify can surface the GRPC code of any error in the
+// chain as a tag, even if it's buried under a few Wraps.
+type grpcStatuser interface {
+	GRPCStatus() *status.Status
 }
 
-// InitSentry inits sentry configuration
-// dsn: sentry dsn
-func InitSentry(dsn string) {
-	raven.SetDSN(dsn)
-	sentryInited = true
-}
-
-func convToGoBrake(in []errors.StackFrame) []gobrake.StackFrame {
-	out := make([]gobrake.StackFrame, 0)
-	for _, s := range in {
-		out = append(out, gobrake.StackFrame{
-			File: s.File,
-			Func: s.Func,
-			Line: s.Line,
-		})
+// grpcStatusOf walks err's Unwrap chain and returns the first non-nil GRPC
+// status found. Every *errorExt node implements grpcStatuser regardless of
+// whether a status was actually attached to it, so errors.As would always
+// bind to the outermost node and never see a status attached further down
+// the chain (e.g. WrapWithStatus(inner) followed by a plain Wrap(outer)) -
+// walk manually instead.
+func grpcStatusOf(err error) *status.Status {
+	for cur := err; cur != nil; cur = errors.Unwrap(cur) {
+		if gs, ok := cur.(grpcStatuser); ok {
+			if st := gs.GRPCStatus(); st != nil {
+				return st
+			}
+		}
 	}
-	return out
+	return nil
 }
 
-func convToRollbar(in []errors.StackFrame) rollbar.Stack {
-	out := rollbar.Stack{}
-	for _, s := range in {
-		out = append(out, rollbar.Frame{
-			Filename: s.File,
-			Method:   s.Func,
-			Line:     s.Line,
-		})
+// notifyExtOf walks err's Unwrap chain for NotifyExt nodes. It returns the
+// outermost NotifyExt found (so Notified(true) marks the same node future
+// calls will see first) and whether any node in the chain was created with
+// WrapWithNotifier(..., false). The chain must be walked manually for the
+// same reason as grpcStatusOf: every *errorExt implements NotifyExt, so
+// errors.As would always bind to the outermost node and miss a silence
+// recorded deeper in the chain.
+func notifyExtOf(err error) (outermost errors.NotifyExt, silenced bool) {
+	for cur := err; cur != nil; cur = errors.Unwrap(cur) {
+		ext, ok := cur.(errors.NotifyExt)
+		if !ok {
+			continue
+		}
+		if outermost == nil {
+			outermost = ext
+		}
+		if !ext.ShouldNotify() {
+			return outermost, true
+		}
 	}
-	return out
+	return outermost, false
 }
 
-func convToSentry(in errors.ErrorExt) *raven.Stacktrace {
-	out := new(raven.Stacktrace)
-	pcs := in.Callers()
-	frames := make([]*raven.StacktraceFrame, 0)
+type Tags map[string]string
 
-	callersFrames := runtime.CallersFrames(pcs)
+func (tags Tags) isTags() {}
 
-	for {
-		fr, more := callersFrames.Next()
-		if fr.Func != nil {
-			frame := raven.NewStacktraceFrame(fr.PC, fr.Function, fr.File, fr.Line, 3, []string{})
-			if frame != nil {
-				frame.InApp = true
-				frames = append(frames, frame)
-			}
-		}
-		if !more {
-			break
-		}
-	}
-	for i := len(frames)/2 - 1; i >= 0; i-- {
-		opp := len(frames) - 1 - i
-		frames[i], frames[opp] = frames[opp], frames[i]
-	}
-	out.Frames = frames
-	return out
+func (tags Tags) value() map[string]string {
+	return map[string]string(tags)
 }
 
 // parseRawData parses raw data to extra data and tags
@@ -159,7 +126,35 @@ func parseRawData(ctx context.Context, rawData ...interface{}) (extraData map[st
 	return
 }
 
-// Notify notifies error to airbrake, rollbar and sentry if they are inited and error is not ignored
+// mergeNotifyData flattens rawData and any WithScope scopes recorded on ctx
+// into the single tags/extra maps every Backend receives.
+func mergeNotifyData(ctx context.Context, traceID string, list ...interface{}) (tags map[string]string, extra map[string]interface{}) {
+	parsedExtra, tagData := parseRawData(ctx, list...)
+	scopeTags, scopeExtra := scopeExtras(ctx)
+
+	tags = make(map[string]string)
+	for _, t := range tagData {
+		for k, v := range t {
+			tags[k] = v
+		}
+	}
+	for k, v := range scopeTags {
+		tags[k] = v
+	}
+
+	extra = parsedExtra
+	for k, v := range scopeExtra {
+		extra[k] = v
+	}
+
+	if traceID != "" {
+		tags["traceId"] = traceID
+		extra["traceId"] = traceID
+	}
+	return tags, extra
+}
+
+// Notify notifies error to all registered backends if the error is not ignored
 // err: error to notify
 // rawData: extra data to notify with error (can be context.Context, Tags, or any other data)
 // when rawData is context.Context, it will used to get extra data from loggers.FromContext(ctx) and tags from metadata
@@ -167,7 +162,7 @@ func Notify(err error, rawData ...interface{}) error {
 	return NotifyWithLevelAndSkip(err, 2, rollbar.ERR, rawData...)
 }
 
-// NotifyWithLevel notifies error to airbrake, rollbar and sentry if they are inited and error is not ignored
+// NotifyWithLevel notifies error to all registered backends if the error is not ignored
 // err: error to notify
 // level: error level
 // rawData: extra data to notify with error (can be context.Context, Tags, or any other data)
@@ -176,7 +171,7 @@ func NotifyWithLevel(err error, level string, rawData ...interface{}) error {
 	return NotifyWithLevelAndSkip(err, 2, level, rawData...)
 }
 
-// NotifyWithLevelAndSkip notifies error to airbrake, rollbar and sentry if they are inited and error is not ignored
+// NotifyWithLevelAndSkip notifies error to all registered backends if the error is not ignored
 // err: error to notify
 // skip: skip stack frames when notify error
 // level: error level
@@ -187,10 +182,9 @@ func NotifyWithLevelAndSkip(err error, skip int, level string, rawData ...interf
 		return nil
 	}
 
-	if n, ok := err.(errors.NotifyExt); ok {
-		if !n.ShouldNotify() {
-			return err
-		}
+	if n, silenced := notifyExtOf(err); silenced {
+		return err
+	} else if n != nil {
 		n.Notified(true)
 	}
 	return doNotify(err, skip, level, rawData...)
@@ -241,60 +235,33 @@ func doNotify(err error, skip int, level string, rawData ...interface{}) error {
 		}
 	}
 
-	if airbrake != nil {
-		var n *gobrake.Notice
-		n = gobrake.NewNotice(errWithStack, nil, 1)
-		n.Errors[0].Backtrace = convToGoBrake(errWithStack.StackFrame())
-		if len(list) > 0 {
-			m, _ := parseRawData(ctx, list...)
-			for k, v := range m {
-				n.Context[k] = v
-			}
-		}
-		if traceID != "" {
-			n.Context["traceId"] = traceID
-		}
-		airbrake.SendNoticeAsync(n)
+	allow, suppressedSince, suppressedCount := throttle(fingerprintOf(errWithStack))
+	if !allow {
+		log.GetLogger().Log(ctx, loggers.ErrorLevel, skip+1, "err", errWithStack, "stack", errWithStack.StackFrame())
+		return err
 	}
 
-	parsedData, tagData := parseRawData(ctx, list...)
-	if rollbarInited {
-		fields := []*rollbar.Field{}
-		if len(list) > 0 {
-			for k, v := range parsedData {
-				fields = append(fields, &rollbar.Field{Name: k, Data: v})
-			}
-		}
-		if traceID != "" {
-			fields = append(fields, &rollbar.Field{Name: "traceId", Data: traceID})
-		}
-		fields = append(fields, &rollbar.Field{Name: "server", Data: map[string]interface{}{"hostname": getHostname(), "root": getServerRoot()}})
-		rollbar.ErrorWithStack(level, errWithStack, convToRollbar(errWithStack.StackFrame()), fields...)
+	tags, extra := mergeNotifyData(ctx, traceID, list...)
+	if suppressedCount > 0 {
+		tags["suppressed_count"] = strconv.Itoa(suppressedCount)
+		extra["suppressed_count"] = suppressedCount
+		extra["suppressed_since"] = suppressedSince
 	}
-
-	if sentryInited {
-		defLevel := raven.ERROR
-		if level == "critical" {
-			defLevel = raven.FATAL
-		} else if level == "warning" {
-			defLevel = raven.WARNING
-		}
-		ravenExp := raven.NewException(errWithStack, convToSentry(errWithStack))
-		packet := raven.NewPacketWithExtra(errWithStack.Error(), parsedData, ravenExp)
-
-		for _, tags := range tagData {
-			packet.AddTags(tags)
-		}
-
-		packet.Level = defLevel
-		raven.Capture(packet, nil)
+	if st := grpcStatusOf(err); st != nil {
+		tags["grpc_code"] = st.Code().String()
+		extra["grpc_message"] = st.Message()
+	}
+	for _, b := range registeredBackends() {
+		b.Notify(ctx, errWithStack, level, tags, extra)
 	}
 
 	log.GetLogger().Log(ctx, loggers.ErrorLevel, skip+1, "err", errWithStack, "stack", errWithStack.StackFrame())
 	return err
 }
 
-// NotifyWithExclude notifies error to airbrake, rollbar and sentry if they are inited and error is not ignored
+// NotifyWithExclude notifies error to all registered backends if the error is not ignored.
+// The notification is queued onto the async worker pool (see StartAsync) instead of
+// spawning a goroutine per call.
 // err: error to notify
 // rawData: extra data to notify with error (can be context.Context, Tags, or any other data)
 // when rawData is context.Context, it will used to get extra data from loggers.FromContext(ctx) and tags from metadata
@@ -319,21 +286,21 @@ func NotifyWithExclude(err error, rawData ...interface{}) error {
 			list = append(list, rawData[pos])
 		}
 	}
-	go Notify(err, list...)
+	// nil means CloseWithContext has already run and nothing restarted the
+	// pool: shutting down, so drop instead of enqueueing onto a closed pool.
+	if p := asyncPoolOrDefault(); p != nil {
+		p.enqueue(notifyJob{err: err, rawData: list})
+	}
 	return err
 }
 
-// NotifyOnPanic notifies error to airbrake, rollbar and sentry if they are inited and error is not ignored
+// NotifyOnPanic notifies the recovered panic to all registered backends
 // rawData: extra data to notify with error (can be context.Context, Tags, or any other data)
 // when rawData is context.Context, it will used to get extra data from loggers.FromContext(ctx) and tags from metadata
 // this function should be called in defer
 // example: defer NotifyOnPanic(ctx, "some data")
 // example: defer NotifyOnPanic(ctx, "some data", Tags{"tag1": "value1"})
 func NotifyOnPanic(rawData ...interface{}) {
-	if airbrake != nil {
-		defer airbrake.NotifyOnPanic()
-	}
-
 	ctx := context.Background()
 	for _, d := range rawData {
 		if c, ok := d.(context.Context); ok {
@@ -351,34 +318,14 @@ func NotifyOnPanic(rawData ...interface{}) {
 		default:
 			e = errors.NewWithSkip("Panic", 1)
 		}
-		parsedData, tagData := parseRawData(ctx, rawData...)
-		if rollbarInited {
-			rollbar.ErrorWithStack(rollbar.CRIT, e, convToRollbar(e.StackFrame()), &rollbar.Field{Name: "panic", Data: r})
-		}
-		if sentryInited {
-			ravenExp := raven.NewException(e, convToSentry(e))
-			packet := raven.NewPacketWithExtra(e.Error(), parsedData, ravenExp)
-
-			for _, tags := range tagData {
-				packet.AddTags(tags)
-			}
-
-			packet.Level = raven.FATAL
-			raven.Capture(packet, nil)
+		tags, extra := mergeNotifyData(ctx, GetTraceId(ctx), rawData...)
+		for _, b := range registeredBackends() {
+			b.NotifyPanic(ctx, e, r, tags, extra)
 		}
 		panic(e)
 	}
 }
 
-// Close closes the airbrake notifier and flushes the error queue.
-// You should call Close before app shutdown.
-// Close doesn't call os.Exit.
-func Close() {
-	if airbrake != nil {
-		airbrake.Close()
-	}
-}
-
 // SetEnvironment sets the environment.
 // The environment is used to distinguish errors occurring in different
 func SetEnvironment(env string) {
@@ -389,43 +336,56 @@ func SetEnvironment(env string) {
 		})
 	}
 	rollbar.Environment = env
-	raven.SetEnvironment(env)
+	sentryEnvironment = env
+	if sentryInited {
+		sentry.ConfigureScope(func(scope *sentry.Scope) {
+			scope.SetTag("environment", env)
+		})
+	}
 }
 
 // SetRelease sets the release tag.
 // The release tag is used to group errors together by release.
 func SetRelease(rel string) {
-	raven.SetRelease(rel)
+	sentryRelease = rel
+	if sentryInited {
+		sentry.ConfigureScope(func(scope *sentry.Scope) {
+			scope.SetTag("release", rel)
+		})
+	}
 }
 
-// SetTraceId updates the traceID based on context values
-// if no trace id is found then it will create one and update the context
+// SetTraceId updates the traceID (and span id) based on context values.
+// It prefers an incoming W3C traceparent header, then B3 headers, then the
+// legacy header configured via SetTraceHeaderName, then opentracing
+// baggage; if none are found it generates a spec-compliant trace/span id.
 // You should use the context returned by this function instead of the one passed
 func SetTraceId(ctx context.Context) context.Context {
 	if GetTraceId(ctx) != "" {
 		return ctx
 	}
-	var traceID string
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if id, ok := md["grpcmetadata-"+traceHeader]; ok {
-			traceID = strings.Join(id, ",")
-		} else if id, ok := md[traceHeader]; ok {
-			traceID = strings.Join(id, ",")
+
+	traceID, spanID := extractIncomingTraceHeaders(ctx)
+	if strings.TrimSpace(traceID) == "" {
+		if span := stdopentracing.SpanFromContext(ctx); span != nil {
+			traceID = span.BaggageItem("trace")
 		}
 	}
-	if span := stdopentracing.SpanFromContext(ctx); span != nil && strings.TrimSpace(traceID) == "" {
-		traceID = span.BaggageItem("trace")
+	if strings.TrimSpace(traceID) == "" {
+		traceID = legacyTraceHeader(ctx)
 	}
 	// if no trace id then create one
 	if strings.TrimSpace(traceID) == "" {
-		u, err := uuid.NewRandom()
-		if err != nil {
-			u, _ = uuid.NewUUID()
-		}
-		traceID = u.String()
+		traceID = newTraceID()
+	}
+	if strings.TrimSpace(spanID) == "" {
+		spanID = newSpanID()
 	}
+
 	ctx = loggers.AddToLogContext(ctx, "trace", traceID)
-	return options.AddToOptions(ctx, tracerID, traceID)
+	ctx = loggers.AddToLogContext(ctx, "span", spanID)
+	ctx = options.AddToOptions(ctx, tracerID, traceID)
+	return options.AddToOptions(ctx, spanIDKey, spanID)
 }
 
 // GetTraceId fetches traceID from context
@@ -0,0 +1,233 @@
+package notifier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+)
+
+const (
+	// fingerprintCacheCapacity bounds how many distinct error fingerprints
+	// the dedupe cache remembers before evicting the least recently used one.
+	fingerprintCacheCapacity = 4096
+	// fingerprintFrameDepth is how many top StackFrame entries are folded
+	// into the fingerprint, in addition to the error message.
+	fingerprintFrameDepth = 5
+	// defaultThrottleWindow is how long duplicate notifications for the
+	// same fingerprint are suppressed by default.
+	defaultThrottleWindow = 30 * time.Second
+)
+
+// Stats reports throttle and async-queue counters, see GetStats().
+type Stats struct {
+	Sent        uint64
+	Suppressed  uint64
+	RateLimited uint64
+	QueueDepth  int
+	Dropped     uint64
+}
+
+var (
+	statsSent        uint64
+	statsSuppressed  uint64
+	statsRateLimited uint64
+
+	throttleMu      sync.Mutex
+	throttleWindow  = defaultThrottleWindow
+	fingerprintLRU  = newFingerprintCache(fingerprintCacheCapacity)
+	notifyRateLimit = newTokenBucket(0, 0) // disabled (unlimited) until SetRateLimit is called
+)
+
+// SetRateLimit caps the total number of notifications (across all
+// fingerprints and backends) allowed per second, with burst allowed to
+// exceed perSecond momentarily. Pass perSecond <= 0 to disable the limit.
+func SetRateLimit(perSecond, burst int) {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	notifyRateLimit = newTokenBucket(perSecond, burst)
+}
+
+// SetThrottleWindow sets how long duplicate notifications for the same
+// fingerprint are suppressed. default is 30s.
+func SetThrottleWindow(d time.Duration) {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	throttleWindow = d
+}
+
+// ResetThrottle clears the dedupe cache, the rate limiter state and the
+// Stats counters. Intended for tests.
+func ResetThrottle() {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	fingerprintLRU = newFingerprintCache(fingerprintCacheCapacity)
+	notifyRateLimit.reset()
+	atomic.StoreUint64(&statsSent, 0)
+	atomic.StoreUint64(&statsSuppressed, 0)
+	atomic.StoreUint64(&statsRateLimited, 0)
+}
+
+// GetStats returns a snapshot of the throttle and async-queue counters.
+func GetStats() Stats {
+	return Stats{
+		Sent:        atomic.LoadUint64(&statsSent),
+		Suppressed:  atomic.LoadUint64(&statsSuppressed),
+		RateLimited: atomic.LoadUint64(&statsRateLimited),
+		QueueDepth:  QueueDepth(),
+		Dropped:     atomic.LoadUint64(&statsDropped),
+	}
+}
+
+// fingerprintOf derives a stable key for err from its message and the top
+// frames of its stack, so repeated notifications from the same call site
+// dedupe even when wrapped with slightly different extra data.
+func fingerprintOf(err errors.ErrorExt) string {
+	h := sha256.New()
+	fmt.Fprint(h, err.Error())
+	frames := err.StackFrame()
+	if len(frames) > fingerprintFrameDepth {
+		frames = frames[:fingerprintFrameDepth]
+	}
+	for _, f := range frames {
+		fmt.Fprintf(h, "|%s:%s:%d", f.File, f.Func, f.Line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// throttle decides, for a given fingerprint, whether a notification should
+// go out now. When it returns allow=false the notification is dropped.
+// When allow=true and suppressedCount > 0, the caller should attach
+// suppressedSince/suppressedCount to the event being sent, since they
+// describe duplicates dropped since the last one that made it through.
+func throttle(fp string) (allow bool, suppressedSince time.Time, suppressedCount int) {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+
+	entry, found := fingerprintLRU.get(fp)
+	withinWindow := found && time.Since(entry.lastSent) < throttleWindow
+	rateOK := notifyRateLimit.allow()
+
+	if withinWindow || !rateOK {
+		if entry.suppressedCount == 0 {
+			entry.suppressedSince = time.Now()
+		}
+		entry.suppressedCount++
+		fingerprintLRU.put(fp, entry)
+		if !rateOK {
+			atomic.AddUint64(&statsRateLimited, 1)
+		} else {
+			atomic.AddUint64(&statsSuppressed, 1)
+		}
+		return false, entry.suppressedSince, entry.suppressedCount
+	}
+
+	suppressedSince, suppressedCount = entry.suppressedSince, entry.suppressedCount
+	fingerprintLRU.put(fp, fingerprintEntry{lastSent: time.Now()})
+	atomic.AddUint64(&statsSent, 1)
+	return true, suppressedSince, suppressedCount
+}
+
+type fingerprintEntry struct {
+	lastSent        time.Time
+	suppressedSince time.Time
+	suppressedCount int
+}
+
+// fingerprintCache is a bounded LRU of fingerprint -> fingerprintEntry.
+type fingerprintCache struct {
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type fingerprintCacheItem struct {
+	key   string
+	entry fingerprintEntry
+}
+
+func newFingerprintCache(capacity int) *fingerprintCache {
+	return &fingerprintCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *fingerprintCache) get(key string) (fingerprintEntry, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return fingerprintEntry{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*fingerprintCacheItem).entry, true
+}
+
+func (c *fingerprintCache) put(key string, entry fingerprintEntry) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*fingerprintCacheItem).entry = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+	el := c.ll.PushFront(&fingerprintCacheItem{key: key, entry: entry})
+	c.items[key] = el
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*fingerprintCacheItem).key)
+		}
+	}
+}
+
+// tokenBucket is a simple, mutex-guarded token bucket rate limiter.
+// perSecond <= 0 disables the limit (allow always returns true).
+type tokenBucket struct {
+	mu         sync.Mutex
+	perSecond  float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(perSecond, burst int) *tokenBucket {
+	return &tokenBucket{
+		perSecond:  float64(perSecond),
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+func (b *tokenBucket) allow() bool {
+	if b.perSecond <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.perSecond
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *tokenBucket) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = b.burst
+	b.lastRefill = time.Now()
+}
@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-coldbrew/errors"
+)
+
+func TestThrottleDedupesWithinWindow(t *testing.T) {
+	ResetThrottle()
+	SetThrottleWindow(time.Minute)
+	defer ResetThrottle()
+
+	fp := fingerprintOf(errors.New("boom"))
+
+	allow, _, _ := throttle(fp)
+	if !allow {
+		t.Fatalf("first notification for a fresh fingerprint should be allowed")
+	}
+
+	allow, _, suppressedCount := throttle(fp)
+	if allow {
+		t.Fatalf("duplicate notification within the throttle window should be suppressed")
+	}
+	if suppressedCount != 1 {
+		t.Fatalf("suppressedCount = %d, want 1", suppressedCount)
+	}
+
+	if got := GetStats().Suppressed; got != 1 {
+		t.Fatalf("GetStats().Suppressed = %d, want 1", got)
+	}
+}
+
+func TestThrottleAllowsAfterWindowElapses(t *testing.T) {
+	ResetThrottle()
+	SetThrottleWindow(time.Millisecond)
+	defer ResetThrottle()
+
+	fp := fingerprintOf(errors.New("boom"))
+
+	if allow, _, _ := throttle(fp); !allow {
+		t.Fatalf("first notification should be allowed")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	allow, _, suppressedCount := throttle(fp)
+	if !allow {
+		t.Fatalf("notification after the throttle window elapsed should be allowed")
+	}
+	if suppressedCount != 0 {
+		t.Fatalf("suppressedCount = %d, want 0 once the window has passed", suppressedCount)
+	}
+}
+
+func TestThrottleDistinctFingerprintsDoNotSuppressEachOther(t *testing.T) {
+	ResetThrottle()
+	SetThrottleWindow(time.Minute)
+	defer ResetThrottle()
+
+	a := fingerprintOf(errors.New("boom a"))
+	b := fingerprintOf(errors.New("boom b"))
+
+	if allow, _, _ := throttle(a); !allow {
+		t.Fatalf("first notification for fingerprint a should be allowed")
+	}
+	if allow, _, _ := throttle(b); !allow {
+		t.Fatalf("first notification for distinct fingerprint b should be allowed")
+	}
+}
+
+func TestTokenBucketRateLimit(t *testing.T) {
+	ResetThrottle()
+	SetThrottleWindow(time.Minute)
+	SetRateLimit(1, 1)
+	defer func() {
+		SetRateLimit(0, 0)
+		ResetThrottle()
+	}()
+
+	fps := []string{
+		fingerprintOf(errors.New("e1")),
+		fingerprintOf(errors.New("e2")),
+	}
+
+	if allow, _, _ := throttle(fps[0]); !allow {
+		t.Fatalf("first call should consume the single burst token")
+	}
+	if allow, _, _ := throttle(fps[1]); allow {
+		t.Fatalf("second call on a distinct fingerprint should still be rate-limited")
+	}
+
+	if got := GetStats().RateLimited; got != 1 {
+		t.Fatalf("GetStats().RateLimited = %d, want 1", got)
+	}
+}
+
+func TestFingerprintCacheEvictsOldest(t *testing.T) {
+	c := newFingerprintCache(2)
+	c.put("a", fingerprintEntry{lastSent: time.Now()})
+	c.put("b", fingerprintEntry{lastSent: time.Now()})
+	c.put("c", fingerprintEntry{lastSent: time.Now()})
+
+	if _, ok := c.get("a"); ok {
+		t.Fatalf("a should have been evicted once the cache exceeded its capacity")
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Fatalf("b should still be cached")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Fatalf("c should still be cached")
+	}
+}
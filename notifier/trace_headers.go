@@ -0,0 +1,176 @@
+package notifier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-coldbrew/log/loggers"
+	"github.com/go-coldbrew/options"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	spanIDKey = "spanId"
+
+	traceparentHeader = "traceparent"
+	b3Header          = "b3"
+	b3TraceIDHeader   = "X-B3-TraceId"
+	b3SpanIDHeader    = "X-B3-SpanId"
+	b3SampledHeader   = "X-B3-Sampled"
+)
+
+// newTraceID generates a spec-compliant 16-byte (32 hex char) W3C/B3 trace id.
+func newTraceID() string {
+	b := make([]byte, 16)
+	rand.Read(b) //nolint:errcheck // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b)
+}
+
+// newSpanID generates a spec-compliant 8-byte (16 hex char) W3C/B3 span id.
+func newSpanID() string {
+	b := make([]byte, 8)
+	rand.Read(b) //nolint:errcheck // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b)
+}
+
+// parseTraceparent parses a W3C `traceparent: 00-<trace-id>-<span-id>-<flags>`
+// header value.
+func parseTraceparent(v string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// parseB3Single parses the single-header B3 form:
+// `b3: {traceId}-{spanId}-{sampled}-{parentSpanId}`. Only traceId and spanId
+// are required to be present.
+func parseB3Single(v string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(v, "-")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// extractIncomingTraceHeaders reads the W3C traceparent or B3 headers (in
+// that order of preference) off ctx's incoming GRPC metadata.
+func extractIncomingTraceHeaders(ctx context.Context) (traceID, spanID string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if v := firstMDValue(md, traceparentHeader); v != "" {
+		if tid, sid, ok := parseTraceparent(v); ok {
+			return tid, sid
+		}
+	}
+	if v := firstMDValue(md, b3Header); v != "" {
+		if tid, sid, ok := parseB3Single(v); ok {
+			return tid, sid
+		}
+	}
+	if tid := firstMDValue(md, b3TraceIDHeader); tid != "" {
+		return tid, firstMDValue(md, b3SpanIDHeader)
+	}
+	return "", ""
+}
+
+func firstMDValue(md metadata.MD, key string) string {
+	if vs := md.Get(key); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// legacyTraceHeader reads the configurable x-trace-id-style header (see
+// SetTraceHeaderName), for back-compat with services that predate
+// traceparent/B3 support.
+func legacyTraceHeader(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if id, ok := md["grpcmetadata-"+traceHeader]; ok {
+		return strings.Join(id, ",")
+	}
+	if id, ok := md[traceHeader]; ok {
+		return strings.Join(id, ",")
+	}
+	return ""
+}
+
+// GetSpanId fetches the current span id from ctx, as set by SetTraceId or
+// UpdateTraceId. Returns an empty string if none is set.
+func GetSpanId(ctx context.Context) string {
+	if o := options.FromContext(ctx); o != nil {
+		if data, found := o.Get(spanIDKey); found {
+			return data.(string)
+		}
+	}
+	if logCtx := loggers.FromContext(ctx); logCtx != nil {
+		if data, found := logCtx.Load("span"); found {
+			if spanID, ok := data.(string); ok {
+				return spanID
+			}
+		}
+	}
+	return ""
+}
+
+// fitHex normalizes s to exactly n lowercase hex characters, truncating or
+// left-padding it with zeros, so ids coming from non-compliant legacy
+// headers still produce a well-formed traceparent.
+func fitHex(s string, n int) string {
+	s = strings.ToLower(strings.Map(func(r rune) rune {
+		if strings.ContainsRune("0123456789abcdef", r) {
+			return r
+		}
+		return -1
+	}, s))
+	if len(s) >= n {
+		return s[:n]
+	}
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// InjectTraceHeaders writes ctx's trace/span id onto md as W3C traceparent
+// and B3 headers, so an outbound GRPC call carries the same ids that
+// SetTraceId recorded for the inbound request.
+func InjectTraceHeaders(ctx context.Context, md metadata.MD) {
+	traceID := GetTraceId(ctx)
+	if traceID == "" {
+		return
+	}
+	spanID := GetSpanId(ctx)
+	if spanID == "" {
+		spanID = newSpanID()
+	}
+	md.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-01", fitHex(traceID, 32), fitHex(spanID, 16)))
+	md.Set(b3TraceIDHeader, traceID)
+	md.Set(b3SpanIDHeader, spanID)
+	md.Set(b3SampledHeader, "1")
+	md.Set(traceHeader, traceID)
+}
+
+// InjectTraceHTTPHeaders is InjectTraceHeaders for an outbound http.Header.
+func InjectTraceHTTPHeaders(ctx context.Context, h http.Header) {
+	traceID := GetTraceId(ctx)
+	if traceID == "" {
+		return
+	}
+	spanID := GetSpanId(ctx)
+	if spanID == "" {
+		spanID = newSpanID()
+	}
+	h.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-01", fitHex(traceID, 32), fitHex(spanID, 16)))
+	h.Set(b3TraceIDHeader, traceID)
+	h.Set(b3SpanIDHeader, spanID)
+	h.Set(b3SampledHeader, "1")
+	h.Set(traceHeader, traceID)
+}
@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		name        string
+		value       string
+		wantTraceID string
+		wantSpanID  string
+		wantOK      bool
+	}{
+		{
+			name:        "valid",
+			value:       "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantSpanID:  "00f067aa0ba902b7",
+			wantOK:      true,
+		},
+		{
+			name:  "too few fields",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		},
+		{
+			name:  "short trace id",
+			value: "00-4bf9-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "short span id",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f0-01",
+		},
+		{
+			name:  "garbled",
+			value: "not-a-traceparent-header",
+		},
+		{
+			name:  "empty",
+			value: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			traceID, spanID, ok := parseTraceparent(c.value)
+			if ok != c.wantOK || traceID != c.wantTraceID || spanID != c.wantSpanID {
+				t.Fatalf("parseTraceparent(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					c.value, traceID, spanID, ok, c.wantTraceID, c.wantSpanID, c.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseB3Single(t *testing.T) {
+	cases := []struct {
+		name        string
+		value       string
+		wantTraceID string
+		wantSpanID  string
+		wantOK      bool
+	}{
+		{
+			name:        "trace and span only",
+			value:       "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1",
+			wantTraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+			wantSpanID:  "e457b5a2e4d86bd1",
+			wantOK:      true,
+		},
+		{
+			name:        "trace, span, sampled, and parent span",
+			value:       "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90",
+			wantTraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+			wantSpanID:  "e457b5a2e4d86bd1",
+			wantOK:      true,
+		},
+		{
+			name:  "missing span id",
+			value: "80f198ee56343ba864fe8b2a57d3eff7",
+		},
+		{
+			name:  "empty trace id field",
+			value: "-e457b5a2e4d86bd1",
+		},
+		{
+			name:  "empty",
+			value: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			traceID, spanID, ok := parseB3Single(c.value)
+			if ok != c.wantOK || traceID != c.wantTraceID || spanID != c.wantSpanID {
+				t.Fatalf("parseB3Single(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					c.value, traceID, spanID, ok, c.wantTraceID, c.wantSpanID, c.wantOK)
+			}
+		})
+	}
+}
+
+func TestExtractIncomingTraceHeadersPrecedence(t *testing.T) {
+	t.Run("traceparent wins over b3", func(t *testing.T) {
+		md := metadata.Pairs(
+			traceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			b3Header, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1",
+		)
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+
+		traceID, spanID := extractIncomingTraceHeaders(ctx)
+		if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+			t.Fatalf("traceparent should take precedence over b3, got (%q, %q)", traceID, spanID)
+		}
+	})
+
+	t.Run("b3 single header wins over multi-header b3", func(t *testing.T) {
+		md := metadata.Pairs(
+			b3Header, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1",
+			b3TraceIDHeader, "ffffffffffffffffffffffffffffffff",
+			b3SpanIDHeader, "ffffffffffffffff",
+		)
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+
+		traceID, spanID := extractIncomingTraceHeaders(ctx)
+		if traceID != "80f198ee56343ba864fe8b2a57d3eff7" || spanID != "e457b5a2e4d86bd1" {
+			t.Fatalf("b3 single header should take precedence over multi-header b3, got (%q, %q)", traceID, spanID)
+		}
+	})
+
+	t.Run("falls back to multi-header b3", func(t *testing.T) {
+		md := metadata.Pairs(
+			b3TraceIDHeader, "ffffffffffffffffffffffffffffffff",
+			b3SpanIDHeader, "ffffffffffffffff",
+		)
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+
+		traceID, spanID := extractIncomingTraceHeaders(ctx)
+		if traceID != "ffffffffffffffffffffffffffffffff" || spanID != "ffffffffffffffff" {
+			t.Fatalf("should fall back to multi-header b3, got (%q, %q)", traceID, spanID)
+		}
+	})
+
+	t.Run("garbled traceparent falls back to b3", func(t *testing.T) {
+		md := metadata.Pairs(
+			traceparentHeader, "garbage",
+			b3Header, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1",
+		)
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+
+		traceID, spanID := extractIncomingTraceHeaders(ctx)
+		if traceID != "80f198ee56343ba864fe8b2a57d3eff7" || spanID != "e457b5a2e4d86bd1" {
+			t.Fatalf("a garbled traceparent should fall back to b3, got (%q, %q)", traceID, spanID)
+		}
+	})
+
+	t.Run("no headers present", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+
+		traceID, spanID := extractIncomingTraceHeaders(ctx)
+		if traceID != "" || spanID != "" {
+			t.Fatalf("expected no trace/span id without headers, got (%q, %q)", traceID, spanID)
+		}
+	})
+}
+
+func TestFitHex(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{name: "already exact length", in: "abcd1234", n: 8, want: "abcd1234"},
+		{name: "truncates", in: "abcd1234ef", n: 8, want: "abcd1234"},
+		{name: "pads", in: "1234", n: 8, want: "00001234"},
+		{name: "strips non-hex and pads", in: "zz12zz34", n: 8, want: "00001234"},
+		{name: "uppercase is filtered out, not lowercased", in: "ABCD", n: 8, want: "00000000"},
+		{name: "empty input pads to all zeros", in: "", n: 4, want: "0000"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := fitHex(c.in, c.n); got != c.want {
+				t.Fatalf("fitHex(%q, %d) = %q, want %q", c.in, c.n, got, c.want)
+			}
+		})
+	}
+}
+
+func TestInjectTraceHeadersNoOpsWithoutTraceID(t *testing.T) {
+	md := metadata.MD{}
+	InjectTraceHeaders(context.Background(), md)
+
+	if len(md) != 0 {
+		t.Fatalf("InjectTraceHeaders should not set any header when ctx has no trace id, got %v", md)
+	}
+}
+
+func TestInjectTraceHeadersSetsW3CAndB3(t *testing.T) {
+	ctx := SetTraceId(context.Background())
+	traceID := GetTraceId(ctx)
+
+	md := metadata.MD{}
+	InjectTraceHeaders(ctx, md)
+
+	if got := firstMDValue(md, b3TraceIDHeader); got != traceID {
+		t.Fatalf("X-B3-TraceId = %q, want %q", got, traceID)
+	}
+	if got := firstMDValue(md, traceparentHeader); got == "" {
+		t.Fatalf("traceparent header should be set")
+	}
+	tid, _, ok := parseTraceparent(firstMDValue(md, traceparentHeader))
+	if !ok {
+		t.Fatalf("InjectTraceHeaders should produce a parseable traceparent")
+	}
+	if tid != fitHex(traceID, 32) {
+		t.Fatalf("traceparent trace id = %q, want %q", tid, fitHex(traceID, 32))
+	}
+}